@@ -3,22 +3,49 @@
 package clusterloader
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
+	osexec "os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/eks/cluster-loader/clusterloaderapi"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/httputil"
 	"go.uber.org/zap"
 	"k8s.io/utils/exec"
 )
 
+// clusterLoaderTerminationGracePeriod is how long "run" waits after sending
+// SIGTERM to the clusterloader process before escalating to SIGKILL, giving
+// in-flight measurements a chance to flush their artifacts to
+// "ClusterLoaderReportDir".
+const clusterLoaderTerminationGracePeriod = 15 * time.Second
+
+// clusterloader2 emits klog-formatted lines (e.g. "I0101 00:00:00.000000
+// 1 measurement_manager.go:123] ...") plus a handful of plain progress
+// lines ("Step \"...\" started", "Running ..."). These patterns classify
+// both so "streamOutput" can emit structured events instead of raw text.
+var (
+	reKlogLine    = regexp.MustCompile(`^[IWEF]\d{4} \d{2}:\d{2}:\d{2}\.\d+\s+\d+ (\S+)\] (.*)$`)
+	reStepStarted = regexp.MustCompile(`^Step "(.+)" started$`)
+	reRunning     = regexp.MustCompile(`^Running (.+)$`)
+)
+
 // Config configures cluster loader.
 type Config struct {
 	Logger *zap.Logger
@@ -32,6 +59,18 @@ type Config struct {
 	// ref. https://github.com/kubernetes/perf-tests/tree/master/clusterloader2
 	ClusterLoaderPath        string
 	ClusterLoaderDownloadURL string
+	// ClusterLoaderSHA256 is the expected sha256 digest of the downloaded
+	// clusterloader binary. If set, "downloadClusterLoader" deletes the
+	// binary and fails rather than executing it on a mismatch.
+	ClusterLoaderSHA256 string
+	// ClusterLoaderCosignPublicKey, if set, is a cosign public key used to
+	// verify a ".sig" signature downloaded alongside "ClusterLoaderDownloadURL".
+	// Requires a "cosign" binary on PATH.
+	ClusterLoaderCosignPublicKey string
+	// ClusterLoaderMinisignPublicKey, if set, is a minisign public key used
+	// to verify a ".minisig" signature downloaded alongside
+	// "ClusterLoaderDownloadURL". Requires a "minisign" binary on PATH.
+	ClusterLoaderMinisignPublicKey string
 	// ClusterLoaderTestConfigPath is the clusterloader2 test configuration file.
 	// Set via "--testconfig" flag.
 	ClusterLoaderTestConfigPath string
@@ -41,6 +80,10 @@ type Config struct {
 	// ClusterLoaderLogsPath is the log file path to stream clusterloader binary runs.
 	ClusterLoaderLogsPath string
 
+	// Mode selects how clusterloader2 measurements are executed.
+	// Defaults to "ModeBinary" when empty.
+	Mode Mode
+
 	// Runs is the number of "clusterloader2" runs back-to-back.
 	Runs    int
 	Timeout time.Duration
@@ -66,36 +109,93 @@ type Config struct {
 	CL2EnablePVS              bool
 	PrometheusScrapeKubeProxy bool
 	EnableSystemPodMetrics    bool
+
+	// TestOverrides is a list of clusterloader2 testoverrides YAML file
+	// paths, each rendered into its own "--testoverrides=" flag in order.
+	// Later files take precedence, per clusterloader2's own layering rules.
+	TestOverrides []string
+	// ExtraOverrides carries ad-hoc "CL2_*" keys (e.g.
+	// "CL2_LOAD_TEST_THROUGHPUT", "CL2_USE_ADVANCED_NETWORKING_METRICS")
+	// that this package does not otherwise expose a typed field for. Values
+	// are written as the scalar type they parse as (int, float, bool, or
+	// else a quoted string) and take precedence over "TestOverrides" and the fields above.
+	ExtraOverrides map[string]string
+
+	//
+	//
+	// SLO gates, checked against the aggregated "GetResults" after "Runs" complete.
+	// A zero value disables the corresponding check.
+
+	// APIResponsivenessP99Threshold fails "Start" when the aggregated
+	// "APIResponsiveness" measurement's P99, in milliseconds, exceeds it.
+	APIResponsivenessP99Threshold float64
+	// PodStartupLatencyP99Threshold fails "Start" when the aggregated
+	// "PodStartupLatency" measurement's P99, in milliseconds, exceeds it.
+	PodStartupLatencyP99Threshold float64
+
+	//
+	//
+	// below configure where per-run measurements are published as they complete
+
+	// PrometheusPushGatewayURL, if set, pushes each run's aggregated
+	// measurements to a Prometheus pushgateway after every "Runs" iteration,
+	// labeled with "run_index", "nodes", "pods_per_node", "cl2_enable_pvs".
+	PrometheusPushGatewayURL string
+	// PrometheusRemoteWriteURL, if set, additionally remote-writes the same
+	// samples (e.g. to Amazon Managed Service for Prometheus).
+	PrometheusRemoteWriteURL string
+	// MetricsPort, if non-zero, serves the same run-scoped metrics on
+	// "/metrics" via an in-process HTTP handler for direct scraping.
+	MetricsPort int
 }
 
 // Loader defines cluster loader operations.
 type Loader interface {
 	Start() error
 	Stop()
-	GetResults()
+	// GetResults returns the clusterloader2 measurements aggregated across
+	// "Runs". Results are published once "Start"'s run loop exits; until then,
+	// or if no run completed, it returns an error.
+	GetResults() (clusterloaderapi.Results, error)
+	// Progress returns a channel of classified clusterloader2 output lines
+	// (step transitions, "Running ..." markers, klog lines), emitted as the
+	// binary runs. Reads are best-effort; slow consumers may miss lines.
+	Progress() <-chan string
 }
 
 type loader struct {
 	cfg            Config
 	donec          chan struct{}
 	donecCloseOnce *sync.Once
-
-	rootCtx           context.Context
-	rootCancel        context.CancelFunc
-	testOverridesPath string
+	progressc      chan string
+
+	rootCtx            context.Context
+	rootCancel         context.CancelFunc
+	testOverridesPaths []string
+
+	// resultsMu guards "results", which "startBinary"'s run loop goroutine
+	// (or "startNative", in the goroutine that called "Start") writes and
+	// "GetResults"/"checkSLOs" read, possibly from a different goroutine.
+	resultsMu sync.RWMutex
+	results   clusterloaderapi.Results
+	metrics   *metricsSink
 }
 
 func New(cfg Config) Loader {
 	return &loader{
-		cfg:               cfg,
-		donec:             make(chan struct{}),
-		donecCloseOnce:    new(sync.Once),
-		testOverridesPath: "",
+		cfg:            cfg,
+		donec:          make(chan struct{}),
+		donecCloseOnce: new(sync.Once),
+		progressc:      make(chan string, 100),
 	}
 }
 
+func (ld *loader) Progress() <-chan string {
+	return ld.progressc
+}
+
 func (ld *loader) Start() (err error) {
-	ld.cfg.Logger.Info("starting cluster loader")
+	ld.cfg.Logger.Info("starting cluster loader", zap.String("mode", string(ld.mode())))
 
 	if !fileutil.Exist(ld.cfg.ClusterLoaderTestConfigPath) {
 		ld.cfg.Logger.Warn("clusterloader test config file does not exist", zap.String("path", ld.cfg.ClusterLoaderTestConfigPath))
@@ -109,31 +209,56 @@ func (ld *loader) Start() (err error) {
 		return err
 	}
 
-	if err = ld.downloadClusterLoader(); err != nil {
-		return err
+	if ld.cfg.PrometheusPushGatewayURL != "" || ld.cfg.PrometheusRemoteWriteURL != "" || ld.cfg.MetricsPort != 0 {
+		ld.metrics = newMetricsSink(ld.cfg)
+		ld.metrics.serveMetrics(ld.cfg.MetricsPort)
+		defer ld.metrics.close()
 	}
-	if err = ld.writeTestOverrides(); err != nil {
+
+	if ld.mode() == ModeNative {
+		err = ld.startNative()
+	} else {
+		err = ld.startBinary()
+	}
+	if err != nil {
 		return err
 	}
 
-	args := []string{
-		ld.cfg.ClusterLoaderPath,
-		"--alsologtostderr",
-		"--testconfig=" + ld.cfg.ClusterLoaderTestConfigPath,
-		"--testoverrides=" + ld.testOverridesPath,
-		"--report-dir=" + ld.cfg.ClusterLoaderReportDir,
-		"--nodes=" + fmt.Sprintf("%d", ld.cfg.Nodes),
+	return ld.checkSLOs()
+}
+
+// mode returns the configured "Config.Mode", defaulting to "ModeBinary" so
+// existing callers that never set it keep exec'ing the clusterloader binary.
+func (ld *loader) mode() Mode {
+	if ld.cfg.Mode == "" {
+		return ModeBinary
+	}
+	return ld.cfg.Mode
+}
+
+// startBinary drives clusterloader2 by downloading and exec'ing the
+// "clusterloader" binary "Runs" times back-to-back.
+func (ld *loader) startBinary() (err error) {
+	if err = ld.downloadClusterLoader(); err != nil {
+		return err
 	}
-	if ld.cfg.KubeConfigPath != "" {
-		args = append(args, "--kubeconfig="+ld.cfg.KubeConfigPath)
+	if err = ld.writeTestOverrides(); err != nil {
+		return err
 	}
-	cmd := strings.Join(args, " ")
 
 	donec := make(chan struct{})
+	errc := make(chan error, 1)
 	ld.rootCtx, ld.rootCancel = context.WithTimeout(context.Background(), ld.cfg.Timeout)
 	go func() {
+		defer close(donec)
+		runs := make([]clusterloaderapi.RunResult, 0, ld.cfg.Runs)
+		// aggregate whatever runs completed so far on every exit path (not
+		// just the "all Runs completed" fallthrough), so a Stop()/Timeout
+		// mid-run doesn't discard already-collected measurements.
 		defer func() {
-			close(donec)
+			ld.resultsMu.Lock()
+			ld.results = clusterloaderapi.Aggregate(runs)
+			ld.resultsMu.Unlock()
 		}()
 		for i := 0; i < ld.cfg.Runs; i++ {
 			select {
@@ -141,24 +266,80 @@ func (ld *loader) Start() (err error) {
 				return
 			default:
 			}
-			if err = ld.run(i, args, cmd); err != nil {
-				return err
+
+			reportDir := filepath.Join(ld.cfg.ClusterLoaderReportDir, fmt.Sprintf("run-%d", i))
+			if err := os.MkdirAll(reportDir, 0700); err != nil {
+				errc <- err
+				return
+			}
+			args := []string{
+				ld.cfg.ClusterLoaderPath,
+				"--alsologtostderr",
+				"--testconfig=" + ld.cfg.ClusterLoaderTestConfigPath,
+				"--report-dir=" + reportDir,
+				"--nodes=" + fmt.Sprintf("%d", ld.cfg.Nodes),
+			}
+			for _, p := range ld.testOverridesPaths {
+				args = append(args, "--testoverrides="+p)
+			}
+			if ld.cfg.KubeConfigPath != "" {
+				args = append(args, "--kubeconfig="+ld.cfg.KubeConfigPath)
+			}
+			if err := ld.run(i, args); err != nil {
+				errc <- err
+				return
+			}
+
+			rr, err := clusterloaderapi.ParseRunReportDir(reportDir, i)
+			if err != nil {
+				ld.cfg.Logger.Warn("failed to parse clusterloader report dir", zap.String("report-dir", reportDir), zap.Error(err))
+			}
+			if ld.metrics != nil {
+				if err := ld.metrics.observeAndPush(i, rr); err != nil {
+					ld.cfg.Logger.Warn("failed to publish clusterloader metrics", zap.Int("index", i), zap.Error(err))
+				}
 			}
+			runs = append(runs, rr)
 		}
 	}()
 	select {
 	case <-ld.cfg.Stopc:
 		ld.cfg.Logger.Info("stopping cluster loader")
+	case <-ld.donec:
+		ld.cfg.Logger.Info("stopping cluster loader")
 	case <-ld.rootCtx.Done():
 		ld.cfg.Logger.Info("timed out cluster loader")
 	case <-donec:
 		ld.cfg.Logger.Info("completed cluster loader")
 	}
 	ld.rootCancel()
+	// block until the run loop goroutine above has actually finished writing
+	// "ld.results"/"errc"; "terminate" (via "ld.rootCtx.Done()") bounds how
+	// long that takes, so this does not hang past the configured "Timeout".
+	<-donec
 
+	select {
+	case err = <-errc:
+	default:
+	}
 	return err
 }
 
+// checkSLOs returns a non-nil error if the aggregated "GetResults" breach any
+// configured SLO threshold, so CI can gate on the perf-tests SLOs rather than
+// only on the clusterloader binary's exit code.
+func (ld *loader) checkSLOs() error {
+	ld.resultsMu.RLock()
+	defer ld.resultsMu.RUnlock()
+	if th := ld.cfg.APIResponsivenessP99Threshold; th > 0 && ld.results.APIResponsivenessP99.P99 > th {
+		return fmt.Errorf("APIResponsiveness p99 %.2fms exceeds threshold %.2fms", ld.results.APIResponsivenessP99.P99, th)
+	}
+	if th := ld.cfg.PodStartupLatencyP99Threshold; th > 0 && ld.results.PodStartupLatencyP99.P99 > th {
+		return fmt.Errorf("PodStartupLatency p99 %.2fms exceeds threshold %.2fms", ld.results.PodStartupLatencyP99.P99, th)
+	}
+	return nil
+}
+
 func (ld *loader) Stop() {
 	ld.cfg.Logger.Info("stopping and waiting for cluster loader")
 	ld.donecCloseOnce.Do(func() {
@@ -167,8 +348,13 @@ func (ld *loader) Stop() {
 	ld.cfg.Logger.Info("stopped and waited for cluster loader")
 }
 
-func (ld *loader) GetResults() {
-
+func (ld *loader) GetResults() (clusterloaderapi.Results, error) {
+	ld.resultsMu.RLock()
+	defer ld.resultsMu.RUnlock()
+	if len(ld.results.Runs) == 0 {
+		return clusterloaderapi.Results{}, errors.New("no results available; has a run of Start completed yet")
+	}
+	return ld.results, nil
 }
 
 func (ld *loader) downloadClusterLoader() (err error) {
@@ -185,6 +371,12 @@ func (ld *loader) downloadClusterLoader() (err error) {
 	} else {
 		ld.cfg.Logger.Info("skipping clusterloader download; already exist", zap.String("clusterloader-path", ld.cfg.ClusterLoaderPath))
 	}
+	if err = ld.verifyClusterLoader(); err != nil {
+		if rerr := os.Remove(ld.cfg.ClusterLoaderPath); rerr != nil {
+			ld.cfg.Logger.Warn("failed to remove unverified clusterloader binary", zap.Error(rerr))
+		}
+		return err
+	}
 	if err = fileutil.EnsureExecutable(ld.cfg.ClusterLoaderPath); err != nil {
 		// file may be already executable while the process does not own the file/directory
 		// ref. https://github.com/aws/aws-k8s-tester/issues/66
@@ -205,25 +397,202 @@ func (ld *loader) downloadClusterLoader() (err error) {
 	return err
 }
 
+// verifyClusterLoader checksums "ClusterLoaderPath" against
+// "ClusterLoaderSHA256" and, independently, verifies a cosign and/or
+// minisign signature when those public keys are configured, so a
+// compromised download mirror or mitm'd binary is caught before it's ever
+// exec'd. Each check is a no-op (with a warning, for the checksum) when its
+// corresponding config field is unset.
+func (ld *loader) verifyClusterLoader() error {
+	if ld.cfg.ClusterLoaderSHA256 == "" {
+		ld.cfg.Logger.Warn("no ClusterLoaderSHA256 configured; skipping clusterloader binary checksum verification")
+	} else {
+		digest, err := sha256File(ld.cfg.ClusterLoaderPath)
+		if err != nil {
+			return fmt.Errorf("failed to checksum %q (%v)", ld.cfg.ClusterLoaderPath, err)
+		}
+		if !strings.EqualFold(digest, ld.cfg.ClusterLoaderSHA256) {
+			return fmt.Errorf("clusterloader binary %q sha256 %q does not match configured %q", ld.cfg.ClusterLoaderPath, digest, ld.cfg.ClusterLoaderSHA256)
+		}
+		ld.cfg.Logger.Info("verified clusterloader binary checksum", zap.String("sha256", digest))
+
+		if ld.cfg.ClusterLoaderReportDir != "" {
+			digestPath := filepath.Join(ld.cfg.ClusterLoaderReportDir, "clusterloader.sha256")
+			if werr := os.WriteFile(digestPath, []byte(digest+"\n"), 0600); werr != nil {
+				ld.cfg.Logger.Warn("failed to persist clusterloader digest", zap.Error(werr))
+			}
+		}
+	}
+
+	if ld.cfg.ClusterLoaderCosignPublicKey != "" {
+		if err := ld.verifyCosignSignature(); err != nil {
+			return err
+		}
+	}
+	if ld.cfg.ClusterLoaderMinisignPublicKey != "" {
+		if err := ld.verifyMinisignSignature(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sha256File streams "path" through "sha256.New" and returns its hex digest,
+// without loading the whole file into memory.
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyCosignSignature downloads the ".sig" file alongside
+// "ClusterLoaderDownloadURL" and verifies it against
+// "ClusterLoaderCosignPublicKey" via the "cosign" binary.
+func (ld *loader) verifyCosignSignature() error {
+	sigPath := ld.cfg.ClusterLoaderPath + ".sig"
+	if err := httputil.Download(ld.cfg.Logger, os.Stderr, ld.cfg.ClusterLoaderDownloadURL+".sig", sigPath); err != nil {
+		return fmt.Errorf("failed to download clusterloader cosign signature (%v)", err)
+	}
+	keyPath, err := fileutil.WriteTempFile([]byte(ld.cfg.ClusterLoaderCosignPublicKey))
+	if err != nil {
+		return fmt.Errorf("failed to write cosign public key (%v)", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	output, err := exec.New().CommandContext(ctx, "cosign", "verify-blob",
+		"--key", keyPath,
+		"--signature", sigPath,
+		ld.cfg.ClusterLoaderPath,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cosign verify-blob failed (%v): %s", err, strings.TrimSpace(string(output)))
+	}
+	ld.cfg.Logger.Info("verified clusterloader cosign signature")
+	return nil
+}
+
+// verifyMinisignSignature downloads the ".minisig" file alongside
+// "ClusterLoaderDownloadURL" and verifies it against
+// "ClusterLoaderMinisignPublicKey" via the "minisign" binary.
+func (ld *loader) verifyMinisignSignature() error {
+	sigPath := ld.cfg.ClusterLoaderPath + ".minisig"
+	if err := httputil.Download(ld.cfg.Logger, os.Stderr, ld.cfg.ClusterLoaderDownloadURL+".minisig", sigPath); err != nil {
+		return fmt.Errorf("failed to download clusterloader minisign signature (%v)", err)
+	}
+	keyPath, err := fileutil.WriteTempFile([]byte(ld.cfg.ClusterLoaderMinisignPublicKey))
+	if err != nil {
+		return fmt.Errorf("failed to write minisign public key (%v)", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	output, err := exec.New().CommandContext(ctx, "minisign",
+		"-V",
+		"-p", keyPath,
+		"-x", sigPath,
+		"-m", ld.cfg.ClusterLoaderPath,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("minisign verification failed (%v): %s", err, strings.TrimSpace(string(output)))
+	}
+	ld.cfg.Logger.Info("verified clusterloader minisign signature")
+	return nil
+}
+
+// writeTestOverrides renders this package's fixed "Config" fields into a
+// generated testoverrides file, then lays "TestOverrides" and
+// "ExtraOverrides" on top of it, each as its own file, so
+// "ld.testOverridesPaths" holds the full ordered list of "--testoverrides="
+// flags clusterloader2 expects (later files win).
 func (ld *loader) writeTestOverrides() (err error) {
+	ld.testOverridesPaths = nil
+
 	buf := bytes.NewBuffer(nil)
 	tpl := template.Must(template.New("TemplateTestOverrides").Parse(TemplateTestOverrides))
-	if err := tpl.Execute(buf, ld.cfg); err != nil {
+	if err = tpl.Execute(buf, ld.cfg); err != nil {
 		return err
 	}
-
 	fmt.Printf("test overrides configuration:\n\n%s\n\n", buf.String())
-
-	ld.testOverridesPath, err = fileutil.WriteTempFile(buf.Bytes())
+	basePath, err := fileutil.WriteTempFile(buf.Bytes())
 	if err != nil {
 		ld.cfg.Logger.Warn("failed to write", zap.Error(err))
 		return err
 	}
+	ld.cfg.Logger.Info("wrote test overrides file", zap.String("path", basePath))
+	ld.testOverridesPaths = append(ld.testOverridesPaths, basePath)
+
+	for _, p := range ld.cfg.TestOverrides {
+		if !fileutil.Exist(p) {
+			return fmt.Errorf("testoverrides file %q not found", p)
+		}
+		ld.cfg.Logger.Info("using user-supplied test overrides file", zap.String("path", p))
+		ld.testOverridesPaths = append(ld.testOverridesPaths, p)
+	}
 
-	ld.cfg.Logger.Info("wrote test overrides file", zap.String("path", ld.testOverridesPath))
+	if len(ld.cfg.ExtraOverrides) == 0 {
+		return nil
+	}
+	extraBuf := bytes.NewBuffer(nil)
+	for _, k := range sortedKeys(ld.cfg.ExtraOverrides) {
+		line, verr := renderOverrideLine(k, ld.cfg.ExtraOverrides[k])
+		if verr != nil {
+			return verr
+		}
+		extraBuf.WriteString(line)
+	}
+	fmt.Printf("extra overrides configuration:\n\n%s\n\n", extraBuf.String())
+	extraPath, err := fileutil.WriteTempFile(extraBuf.Bytes())
+	if err != nil {
+		ld.cfg.Logger.Warn("failed to write", zap.Error(err))
+		return err
+	}
+	ld.cfg.Logger.Info("wrote extra overrides file", zap.String("path", extraPath))
+	ld.testOverridesPaths = append(ld.testOverridesPaths, extraPath)
 	return nil
 }
 
+// renderOverrideLine validates that "key" is non-empty and contains no
+// whitespace or ':' (no "CL2_" prefix is required) and renders "KEY: value" with "value" written as the
+// scalar type it parses as (int, float, bool, or else a quoted string),
+// since clusterloader2 silently ignores malformed overrides rather than
+// erroring. Int/float are tried before bool so numeric strings like "0" and
+// "1" render as numbers rather than "strconv.ParseBool" silently accepting
+// them as booleans.
+func renderOverrideLine(key, value string) (string, error) {
+	if key == "" || strings.ContainsAny(key, " \t\n:") {
+		return "", fmt.Errorf("invalid override key %q", key)
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return fmt.Sprintf("%s: %d\n", key, n), nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return fmt.Sprintf("%s: %g\n", key, f), nil
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return fmt.Sprintf("%s: %t\n", key, b), nil
+	}
+	return fmt.Sprintf("%s: %q\n", key, value), nil
+}
+
+// sortedKeys returns the keys of "m" in ascending order, so generated
+// overrides files are deterministic across runs.
+func sortedKeys(m map[string]string) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
 const TemplateTestOverrides = `NODES_PER_NAMESPACE: {{ .NodesPerNamespace }}
 PODS_PER_NODE: {{ .PodsPerNode }}
 BIG_GROUP_SIZE: {{ .BigGroupSize }}
@@ -239,11 +608,119 @@ ENABLE_SYSTEM_POD_METRICS: {{ .EnableSystemPodMetrics }}
 func (ld *loader) run(idx int, args []string) (err error) {
 	ld.cfg.Logger.Info("running cluster loader", zap.Int("index", idx), zap.String("command", strings.Join(args, " ")))
 	ctx, cancel := context.WithTimeout(ld.rootCtx, 20*time.Minute)
-	cmd := exec.New().CommandContext(ctx, args[0], args[1:]...)
-	output, err := cmd.CombinedOutput()
-	cancel()
+	defer cancel()
+
+	cmd := osexec.Command(args[0], args[1:]...)
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stderrR, stderrW, err := os.Pipe()
 	if err != nil {
-		ld.cfg.Logger.Warn("failed to run cluster loader", zap.Error(err))
+		return err
+	}
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+
+	var logFile *os.File
+	if ld.cfg.ClusterLoaderLogsPath != "" {
+		logFile, err = os.OpenFile(ld.cfg.ClusterLoaderLogsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open %q (%v)", ld.cfg.ClusterLoaderLogsPath, err)
+		}
+		defer logFile.Close()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go ld.streamOutput(&wg, idx, "stdout", stdoutR, logFile)
+	go ld.streamOutput(&wg, idx, "stderr", stderrR, logFile)
+
+	if err = cmd.Start(); err != nil {
+		stdoutW.Close()
+		stderrW.Close()
+		wg.Wait()
+		return fmt.Errorf("failed to start cluster loader (%v)", err)
+	}
+
+	waitc := make(chan error, 1)
+	go func() {
+		waitc <- cmd.Wait()
+		stdoutW.Close()
+		stderrW.Close()
+	}()
+
+	select {
+	case err = <-waitc:
+	case <-ld.cfg.Stopc:
+		err = ld.terminate(cmd, waitc)
+	case <-ctx.Done():
+		err = ld.terminate(cmd, waitc)
+	}
+
+	wg.Wait()
+	if err != nil {
+		ld.cfg.Logger.Warn("failed to run cluster loader", zap.Int("index", idx), zap.Error(err))
 	}
 	return err
 }
+
+// terminate sends SIGTERM to "cmd" and waits up to
+// "clusterLoaderTerminationGracePeriod" for it to exit before escalating to
+// SIGKILL, so any in-flight measurement artifacts are flushed to
+// "ClusterLoaderReportDir" rather than lost.
+func (ld *loader) terminate(cmd *osexec.Cmd, waitc chan error) error {
+	if cmd.Process == nil {
+		return <-waitc
+	}
+	ld.cfg.Logger.Info("sending SIGTERM to cluster loader", zap.Int("pid", cmd.Process.Pid))
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case err := <-waitc:
+		return err
+	case <-time.After(clusterLoaderTerminationGracePeriod):
+		ld.cfg.Logger.Warn("cluster loader did not exit after SIGTERM; sending SIGKILL", zap.Int("pid", cmd.Process.Pid))
+		_ = cmd.Process.Kill()
+		return <-waitc
+	}
+}
+
+// streamOutput scans "r" line by line, tees each line to "logFile" (when
+// configured), and classifies it via "emitProgress" until "r" is closed.
+func (ld *loader) streamOutput(wg *sync.WaitGroup, idx int, stream string, r io.Reader, logFile *os.File) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if logFile != nil {
+			fmt.Fprintln(logFile, line)
+		}
+		ld.emitProgress(idx, stream, line)
+	}
+}
+
+// emitProgress classifies a single clusterloader2 output line, logs it as a
+// structured zap event, and forwards it to "Progress" on a best-effort basis.
+func (ld *loader) emitProgress(idx int, stream, line string) {
+	switch {
+	case reStepStarted.MatchString(line):
+		m := reStepStarted.FindStringSubmatch(line)
+		ld.cfg.Logger.Info("clusterloader step started", zap.Int("index", idx), zap.String("step", m[1]))
+	case reRunning.MatchString(line):
+		m := reRunning.FindStringSubmatch(line)
+		ld.cfg.Logger.Info("clusterloader running", zap.Int("index", idx), zap.String("target", m[1]))
+	case reKlogLine.MatchString(line):
+		m := reKlogLine.FindStringSubmatch(line)
+		ld.cfg.Logger.Debug("clusterloader log", zap.Int("index", idx), zap.String("source", m[1]), zap.String("message", m[2]))
+	default:
+		ld.cfg.Logger.Debug("clusterloader output", zap.Int("index", idx), zap.String("stream", stream), zap.String("line", line))
+	}
+
+	select {
+	case ld.progressc <- line:
+	default:
+		ld.cfg.Logger.Warn("dropped clusterloader progress line; consumer too slow", zap.Int("index", idx))
+	}
+}
@@ -0,0 +1,121 @@
+package clusterloaderapi
+
+import (
+	"math"
+	"sort"
+)
+
+// Stats summarizes a metric sampled once per "Runs" iteration.
+type Stats struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+}
+
+// Results is the aggregate of every "RunResult" produced across the
+// configured number of "Runs".
+type Results struct {
+	Runs []RunResult `json:"runs"`
+
+	// APIResponsivenessP99 aggregates, across runs, the worst (max) "Perc99"
+	// data item reported by the "APIResponsiveness" measurement.
+	APIResponsivenessP99 Stats `json:"apiResponsivenessP99"`
+	// PodStartupLatencyP99 aggregates, across runs, the worst (max) "Perc99"
+	// data item reported by the "PodStartupLatency" measurement.
+	PodStartupLatencyP99 Stats `json:"podStartupLatencyP99"`
+	// SchedulingThroughputP50 aggregates, across runs, the "Perc50" data item
+	// reported by the "SchedulingThroughput" measurement.
+	SchedulingThroughputP50 Stats `json:"schedulingThroughputP50"`
+}
+
+// Aggregate computes "Results" across all parsed "RunResult" values.
+func Aggregate(runs []RunResult) Results {
+	rs := Results{Runs: runs}
+	rs.APIResponsivenessP99 = computeStats(extractPerc(runs, "Perc99", func(rr RunResult) *PerfData {
+		return (*PerfData)(rr.APIResponsiveness)
+	}))
+	rs.PodStartupLatencyP99 = computeStats(extractPerc(runs, "Perc99", func(rr RunResult) *PerfData {
+		return (*PerfData)(rr.PodStartupLatency)
+	}))
+	rs.SchedulingThroughputP50 = computeStats(extractPerc(runs, "Perc50", func(rr RunResult) *PerfData {
+		return (*PerfData)(rr.SchedulingThroughput)
+	}))
+	return rs
+}
+
+// extractPerc collects, for each run, the worst (max) value of "key" (e.g.
+// "Perc99") across every "DataItem" of the measurement "get" returns. Runs
+// where the measurement was not produced are skipped.
+func extractPerc(runs []RunResult, key string, get func(RunResult) *PerfData) []float64 {
+	vs := make([]float64, 0, len(runs))
+	for _, rr := range runs {
+		pd := get(rr)
+		if pd == nil {
+			continue
+		}
+		if v, ok := WorstPercentile(pd, key); ok {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// WorstPercentile returns the largest value of "key" (e.g. "Perc99") across
+// every "DataItem" in "pd". It returns false if "pd" is nil or none of its
+// data items carry "key".
+func WorstPercentile(pd *PerfData, key string) (float64, bool) {
+	if pd == nil {
+		return 0, false
+	}
+	worst, ok := math.Inf(-1), false
+	for _, di := range pd.DataItems {
+		if v, found := di.Data[key]; found {
+			ok = true
+			if v > worst {
+				worst = v
+			}
+		}
+	}
+	return worst, ok
+}
+
+// computeStats returns the min/max/mean/p50/p90/p99 of "vs" using
+// nearest-rank percentiles. It returns the zero "Stats" for an empty input.
+func computeStats(vs []float64) Stats {
+	if len(vs) == 0 {
+		return Stats{}
+	}
+	sorted := append([]float64(nil), vs...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	return Stats{
+		Min:  sorted[0],
+		Max:  sorted[len(sorted)-1],
+		Mean: sum / float64(len(sorted)),
+		P50:  percentile(sorted, 50),
+		P90:  percentile(sorted, 90),
+		P99:  percentile(sorted, 99),
+	}
+}
+
+// percentile returns the nearest-rank "p"th percentile of the already-sorted "sorted".
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,83 @@
+package clusterloaderapi
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{p: 50, want: 5},
+		{p: 90, want: 9},
+		{p: 99, want: 10},
+		{p: 100, want: 10},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(sorted, %.0f) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPercentileSingleValue(t *testing.T) {
+	if got := percentile([]float64{42}, 99); got != 42 {
+		t.Errorf("percentile single value = %v, want 42", got)
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	if got := computeStats(nil); got != (Stats{}) {
+		t.Errorf("computeStats(nil) = %+v, want zero value", got)
+	}
+
+	stats := computeStats([]float64{3, 1, 2})
+	if stats.Min != 1 || stats.Max != 3 {
+		t.Errorf("computeStats min/max = %v/%v, want 1/3", stats.Min, stats.Max)
+	}
+	if stats.Mean != 2 {
+		t.Errorf("computeStats mean = %v, want 2", stats.Mean)
+	}
+}
+
+func TestWorstPercentile(t *testing.T) {
+	if _, ok := WorstPercentile(nil, "Perc99"); ok {
+		t.Error("WorstPercentile(nil, ...) should report not found")
+	}
+
+	pd := &PerfData{DataItems: []DataItem{
+		{Data: map[string]float64{"Perc99": 100}},
+		{Data: map[string]float64{"Perc99": 250}},
+		{Data: map[string]float64{"Perc50": 10}},
+	}}
+	v, ok := WorstPercentile(pd, "Perc99")
+	if !ok || v != 250 {
+		t.Errorf("WorstPercentile(pd, Perc99) = %v, %v, want 250, true", v, ok)
+	}
+	if _, ok := WorstPercentile(pd, "Perc999"); ok {
+		t.Error("WorstPercentile should report not found for an absent key")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	runs := []RunResult{
+		{
+			Index:             0,
+			APIResponsiveness: &APIResponsiveness{DataItems: []DataItem{{Data: map[string]float64{"Perc99": 100}}}},
+		},
+		{
+			Index:             1,
+			APIResponsiveness: &APIResponsiveness{DataItems: []DataItem{{Data: map[string]float64{"Perc99": 200}}}},
+		},
+	}
+	rs := Aggregate(runs)
+	if len(rs.Runs) != 2 {
+		t.Fatalf("Aggregate(runs).Runs has %d entries, want 2", len(rs.Runs))
+	}
+	if rs.APIResponsivenessP99.Max != 200 {
+		t.Errorf("APIResponsivenessP99.Max = %v, want 200", rs.APIResponsivenessP99.Max)
+	}
+	if rs.PodStartupLatencyP99 != (Stats{}) {
+		t.Errorf("PodStartupLatencyP99 = %+v, want zero value (no runs reported it)", rs.PodStartupLatencyP99)
+	}
+}
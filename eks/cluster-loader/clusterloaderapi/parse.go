@@ -0,0 +1,96 @@
+package clusterloaderapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RunResult holds the parsed clusterloader2 JSON artifacts for a single run.
+// Fields are nil when the corresponding artifact was not produced (e.g. a
+// measurement was not configured in the "testconfig").
+type RunResult struct {
+	// Index is the 0-based "Runs" iteration this result belongs to.
+	Index int
+
+	APIResponsiveness    *APIResponsiveness
+	PodStartupLatency    *PodStartupLatency
+	SchedulingThroughput *SchedulingThroughput
+	SystemPodMetrics     *SystemPodMetrics
+	ResourceUsageSummary *ResourceUsageSummary
+}
+
+// artifacts maps the glob pattern clusterloader2 uses for a measurement's
+// JSON artifact to the field on "RunResult" it should be parsed into.
+var artifacts = []struct {
+	pattern string
+	parse   func(b []byte, rr *RunResult) error
+}{
+	{"APIResponsiveness*.json", func(b []byte, rr *RunResult) error {
+		v := new(APIResponsiveness)
+		if err := json.Unmarshal(b, v); err != nil {
+			return err
+		}
+		rr.APIResponsiveness = v
+		return nil
+	}},
+	{"PodStartupLatency*.json", func(b []byte, rr *RunResult) error {
+		v := new(PodStartupLatency)
+		if err := json.Unmarshal(b, v); err != nil {
+			return err
+		}
+		rr.PodStartupLatency = v
+		return nil
+	}},
+	{"SchedulingThroughput*.json", func(b []byte, rr *RunResult) error {
+		v := new(SchedulingThroughput)
+		if err := json.Unmarshal(b, v); err != nil {
+			return err
+		}
+		rr.SchedulingThroughput = v
+		return nil
+	}},
+	{"SystemPodMetrics*.json", func(b []byte, rr *RunResult) error {
+		v := new(SystemPodMetrics)
+		if err := json.Unmarshal(b, v); err != nil {
+			return err
+		}
+		rr.SystemPodMetrics = v
+		return nil
+	}},
+	{"ResourceUsageSummary*.json", func(b []byte, rr *RunResult) error {
+		v := new(ResourceUsageSummary)
+		if err := json.Unmarshal(b, v); err != nil {
+			return err
+		}
+		rr.ResourceUsageSummary = v
+		return nil
+	}},
+}
+
+// ParseRunReportDir reads every recognized clusterloader2 JSON artifact out
+// of "dir" (a single run's "--report-dir") and returns them as a "RunResult".
+// Missing artifacts are not an error; clusterloader2 only writes the files
+// for measurements enabled in the "testconfig".
+func ParseRunReportDir(dir string, index int) (RunResult, error) {
+	rr := RunResult{Index: index}
+	for _, a := range artifacts {
+		matches, err := filepath.Glob(filepath.Join(dir, a.pattern))
+		if err != nil {
+			return RunResult{}, fmt.Errorf("failed to glob %q in %q (%v)", a.pattern, dir, err)
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		// clusterloader2 writes at most one file per pattern per run; take the first match.
+		b, err := os.ReadFile(matches[0])
+		if err != nil {
+			return RunResult{}, fmt.Errorf("failed to read %q (%v)", matches[0], err)
+		}
+		if err = a.parse(b, &rr); err != nil {
+			return RunResult{}, fmt.Errorf("failed to parse %q (%v)", matches[0], err)
+		}
+	}
+	return rr, nil
+}
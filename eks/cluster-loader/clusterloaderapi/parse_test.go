@@ -0,0 +1,56 @@
+package clusterloaderapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRunReportDirMissingArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	rr, err := ParseRunReportDir(dir, 0)
+	if err != nil {
+		t.Fatalf("ParseRunReportDir: %v", err)
+	}
+	if rr.Index != 0 || rr.APIResponsiveness != nil || rr.PodStartupLatency != nil {
+		t.Errorf("ParseRunReportDir on an empty dir = %+v, want zero-value fields", rr)
+	}
+}
+
+func TestParseRunReportDir(t *testing.T) {
+	dir := t.TempDir()
+	const apiResponsivenessJSON = `{
+		"version": "v1",
+		"dataItems": [{"data": {"Perc99": 123.4}, "unit": "ms"}]
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "APIResponsiveness_load_test.json"), []byte(apiResponsivenessJSON), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	rr, err := ParseRunReportDir(dir, 3)
+	if err != nil {
+		t.Fatalf("ParseRunReportDir: %v", err)
+	}
+	if rr.Index != 3 {
+		t.Errorf("rr.Index = %d, want 3", rr.Index)
+	}
+	if rr.APIResponsiveness == nil {
+		t.Fatal("rr.APIResponsiveness is nil, want parsed artifact")
+	}
+	if v, ok := WorstPercentile((*PerfData)(rr.APIResponsiveness), "Perc99"); !ok || v != 123.4 {
+		t.Errorf("APIResponsiveness Perc99 = %v, %v, want 123.4, true", v, ok)
+	}
+	if rr.PodStartupLatency != nil {
+		t.Errorf("rr.PodStartupLatency = %+v, want nil (no matching artifact written)", rr.PodStartupLatency)
+	}
+}
+
+func TestParseRunReportDirMalformedArtifact(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "APIResponsiveness_load_test.json"), []byte("not json"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := ParseRunReportDir(dir, 0); err == nil {
+		t.Error("ParseRunReportDir with a malformed artifact should return an error")
+	}
+}
@@ -0,0 +1,41 @@
+// Package clusterloaderapi defines the JSON artifact formats written by
+// clusterloader2 into its "--report-dir" and the aggregated result types
+// surfaced by the "clusterloader" package.
+// ref. https://github.com/kubernetes/perf-tests/tree/master/clusterloader2/pkg/measurement/util/perftype
+package clusterloaderapi
+
+// PerfData is the common envelope clusterloader2 measurements marshal to
+// JSON in the report directory (e.g. "APIResponsiveness_*.json").
+type PerfData struct {
+	// Version is the schema version of the data format.
+	Version string `json:"version"`
+	// DataItems is the set of recorded metrics for this measurement.
+	DataItems []DataItem `json:"dataItems"`
+	// Labels apply to every item in "DataItems".
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// DataItem is a single labeled metric within a "PerfData" document.
+type DataItem struct {
+	// Data maps a percentile/aggregation name (e.g. "Perc50", "Perc99") to its value.
+	Data map[string]float64 `json:"data"`
+	// Unit is the unit "Data" values are reported in (e.g. "ms").
+	Unit string `json:"unit"`
+	// Labels identify what "Data" measures (e.g. "Verb", "Resource", "Scope").
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// APIResponsiveness is the parsed contents of "APIResponsiveness*.json".
+type APIResponsiveness PerfData
+
+// PodStartupLatency is the parsed contents of "PodStartupLatency*.json".
+type PodStartupLatency PerfData
+
+// SchedulingThroughput is the parsed contents of "SchedulingThroughput*.json".
+type SchedulingThroughput PerfData
+
+// SystemPodMetrics is the parsed contents of "SystemPodMetrics*.json".
+type SystemPodMetrics PerfData
+
+// ResourceUsageSummary is the parsed contents of "ResourceUsageSummary*.json".
+type ResourceUsageSummary PerfData
@@ -0,0 +1,184 @@
+package clusterloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks/cluster-loader/clusterloaderapi"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/prometheus/prompb"
+	"go.uber.org/zap"
+)
+
+// metricsLabelNames are the labels attached to every run-scoped gauge, so
+// Grafana dashboards can slice a back-to-back "Runs" series by the knobs
+// that most commonly change between runs.
+var metricsLabelNames = []string{"run_index", "nodes", "pods_per_node", "cl2_enable_pvs"}
+
+// clusterLoaderMetricsShutdownTimeout bounds how long "metricsSink.close"
+// waits for the "/metrics" server to shut down gracefully.
+const clusterLoaderMetricsShutdownTimeout = 5 * time.Second
+
+// metricsSink publishes each run's aggregated clusterloader2 measurements to
+// a Prometheus pushgateway and/or remote-write endpoint, and optionally
+// serves them directly on "/metrics" for in-process scraping.
+type metricsSink struct {
+	cfg      Config
+	registry *prometheus.Registry
+	server   *http.Server
+
+	apiResponsivenessP99    *prometheus.GaugeVec
+	podStartupLatencyP99    *prometheus.GaugeVec
+	schedulingThroughputP50 *prometheus.GaugeVec
+}
+
+// newMetricsSink registers the run-scoped gauges against a fresh registry.
+// It does not check "PrometheusPushGatewayURL", "PrometheusRemoteWriteURL"
+// or "MetricsPort"; "Start" only creates a sink when one of them is set.
+func newMetricsSink(cfg Config) *metricsSink {
+	registry := prometheus.NewRegistry()
+	return &metricsSink{
+		cfg:      cfg,
+		registry: registry,
+		apiResponsivenessP99: promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "clusterloader_api_responsiveness_p99_milliseconds",
+			Help: "Worst APIResponsiveness Perc99, in milliseconds, observed in a single clusterloader2 run.",
+		}, metricsLabelNames),
+		podStartupLatencyP99: promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "clusterloader_pod_startup_latency_p99_milliseconds",
+			Help: "Worst PodStartupLatency Perc99, in milliseconds, observed in a single clusterloader2 run.",
+		}, metricsLabelNames),
+		schedulingThroughputP50: promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "clusterloader_scheduling_throughput_p50_pods_per_second",
+			Help: "SchedulingThroughput Perc50, in pods/s, observed in a single clusterloader2 run.",
+		}, metricsLabelNames),
+	}
+}
+
+// serveMetrics starts an HTTP server on "port" exposing "/metrics" for
+// direct Prometheus scraping, if "port" is non-zero.
+func (ms *metricsSink) serveMetrics(port int) {
+	if port == 0 {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(ms.registry, promhttp.HandlerOpts{}))
+	ms.server = &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
+	go func() {
+		if err := ms.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			ms.cfg.Logger.Warn("clusterloader metrics server stopped", zap.Error(err))
+		}
+	}()
+	ms.cfg.Logger.Info("serving clusterloader metrics", zap.Int("port", port))
+}
+
+// close shuts down the "/metrics" server started by "serveMetrics", if any.
+func (ms *metricsSink) close() {
+	if ms.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clusterLoaderMetricsShutdownTimeout)
+	defer cancel()
+	if err := ms.server.Shutdown(ctx); err != nil {
+		ms.cfg.Logger.Warn("failed to shut down clusterloader metrics server", zap.Error(err))
+	}
+}
+
+// observeAndPush records "rr" on the run-scoped gauges, labeled with
+// "run_index" and the knobs that commonly change between runs, then pushes
+// them to the configured pushgateway and/or remote-write endpoint.
+func (ms *metricsSink) observeAndPush(idx int, rr clusterloaderapi.RunResult) error {
+	labels := prometheus.Labels{
+		"run_index":      strconv.Itoa(idx),
+		"nodes":          strconv.Itoa(ms.cfg.Nodes),
+		"pods_per_node":  strconv.Itoa(ms.cfg.PodsPerNode),
+		"cl2_enable_pvs": strconv.FormatBool(ms.cfg.CL2EnablePVS),
+	}
+	if v, ok := clusterloaderapi.WorstPercentile((*clusterloaderapi.PerfData)(rr.APIResponsiveness), "Perc99"); ok {
+		ms.apiResponsivenessP99.With(labels).Set(v)
+	}
+	if v, ok := clusterloaderapi.WorstPercentile((*clusterloaderapi.PerfData)(rr.PodStartupLatency), "Perc99"); ok {
+		ms.podStartupLatencyP99.With(labels).Set(v)
+	}
+	if v, ok := clusterloaderapi.WorstPercentile((*clusterloaderapi.PerfData)(rr.SchedulingThroughput), "Perc50"); ok {
+		ms.schedulingThroughputP50.With(labels).Set(v)
+	}
+
+	if ms.cfg.PrometheusPushGatewayURL != "" {
+		// Deliberately not grouped by "run_index": the pushgateway treats each
+		// distinct grouping key as a permanent, independent group, so grouping
+		// by a value that changes every run would accumulate one group per
+		// run forever instead of each push replacing the last. "run_index"
+		// stays on the metric itself (see "metricsLabelNames") so the latest
+		// push still identifies which run it came from.
+		if err := push.New(ms.cfg.PrometheusPushGatewayURL, "clusterloader").
+			Gatherer(ms.registry).
+			Push(); err != nil {
+			return fmt.Errorf("failed to push metrics to %q (%v)", ms.cfg.PrometheusPushGatewayURL, err)
+		}
+	}
+	if ms.cfg.PrometheusRemoteWriteURL != "" {
+		if err := ms.remoteWrite(); err != nil {
+			return fmt.Errorf("failed to remote-write metrics to %q (%v)", ms.cfg.PrometheusRemoteWriteURL, err)
+		}
+	}
+	return nil
+}
+
+// remoteWrite gathers the current registry and POSTs it to
+// "PrometheusRemoteWriteURL" as a snappy-compressed "prompb.WriteRequest".
+func (ms *metricsSink) remoteWrite() error {
+	mfs, err := ms.registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	req := &prompb.WriteRequest{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			labels := []prompb.Label{{Name: "__name__", Value: mf.GetName()}}
+			for _, lp := range m.GetLabel() {
+				labels = append(labels, prompb.Label{Name: lp.GetName(), Value: lp.GetValue()})
+			}
+			req.Timeseries = append(req.Timeseries, prompb.TimeSeries{
+				Labels: labels,
+				Samples: []prompb.Sample{{
+					Value:     m.GetGauge().GetValue(),
+					Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+				}},
+			})
+		}
+	}
+
+	b, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	compressed := snappy.Encode(nil, b)
+
+	httpReq, err := http.NewRequest(http.MethodPost, ms.cfg.PrometheusRemoteWriteURL, bytes.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("remote-write endpoint returned %q", resp.Status)
+	}
+	return nil
+}
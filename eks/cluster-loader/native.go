@@ -0,0 +1,400 @@
+package clusterloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks/cluster-loader/clusterloaderapi"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// Mode is the clusterloader2 execution strategy.
+type Mode string
+
+const (
+	// ModeBinary drives the external "clusterloader" binary via "exec.CommandContext".
+	// Requires "ClusterLoaderDownloadURL" (or a pre-installed "ClusterLoaderPath") to be reachable.
+	ModeBinary Mode = "binary"
+	// ModeNative drives the core clusterloader2 measurements in-process against
+	// "KubeConfigPath" via client-go, without downloading or forking the
+	// "clusterloader" binary. Useful on air-gapped clusters, and lets "Runs > 1"
+	// reuse a single clientset across iterations instead of re-forking a process each run.
+	ModeNative Mode = "native"
+)
+
+// testConfig is the subset of a clusterloader2 "testconfig" YAML document the
+// native driver reads: its name, identifier, namespace count and step names.
+// It does not support the full clusterloader2 templating language (module
+// imports, "{{$var}}" substitution) and does not parse "Objects". The native
+// driver does not execute the steps or create any objects; the core
+// measurements below observe the pods already present in the cluster.
+type testConfig struct {
+	Name       string           `json:"name"`
+	Identifier string           `json:"identifier"`
+	Namespace  testConfigNSSpec `json:"namespace"`
+	Steps      []struct {
+		Name string `json:"name"`
+	} `json:"steps"`
+}
+
+type testConfigNSSpec struct {
+	Number int `json:"number"`
+}
+
+// nativeDriver runs the core clusterloader2 measurements in-process against a
+// single cluster, reusing its clientset across every "Runs" iteration.
+type nativeDriver struct {
+	cfg        Config
+	testConfig testConfig
+	clientset  kubernetes.Interface
+}
+
+// newNativeDriver builds a "nativeDriver" for "cfg", parsing the testconfig
+// YAML and constructing a clientset from "KubeConfigPath" (or in-cluster
+// configuration, if empty).
+func newNativeDriver(cfg Config) (*nativeDriver, error) {
+	b, err := os.ReadFile(cfg.ClusterLoaderTestConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read testconfig %q (%v)", cfg.ClusterLoaderTestConfigPath, err)
+	}
+	tc := testConfig{}
+	if err = yaml.Unmarshal(b, &tc); err != nil {
+		return nil, fmt.Errorf("failed to parse testconfig %q (%v)", cfg.ClusterLoaderTestConfigPath, err)
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", cfg.KubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rest config from %q (%v)", cfg.KubeConfigPath, err)
+	}
+	restConfig.Timeout = cfg.Timeout
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset (%v)", err)
+	}
+
+	return &nativeDriver{cfg: cfg, testConfig: tc, clientset: clientset}, nil
+}
+
+// startNative drives "Runs" iterations of the native clusterloader2
+// measurements, reusing "nativeDriver.clientset" rather than re-forking a
+// process each run, and aggregates the per-run results into "ld.results".
+func (ld *loader) startNative() error {
+	nd, err := newNativeDriver(ld.cfg)
+	if err != nil {
+		return err
+	}
+
+	runs := make([]clusterloaderapi.RunResult, 0, ld.cfg.Runs)
+	for i := 0; i < ld.cfg.Runs; i++ {
+		select {
+		case <-ld.cfg.Stopc:
+			ld.cfg.Logger.Info("stopping native cluster loader")
+			ld.resultsMu.Lock()
+			ld.results = clusterloaderapi.Aggregate(runs)
+			ld.resultsMu.Unlock()
+			return nil
+		case <-ld.donec:
+			ld.cfg.Logger.Info("stopping native cluster loader")
+			ld.resultsMu.Lock()
+			ld.results = clusterloaderapi.Aggregate(runs)
+			ld.resultsMu.Unlock()
+			return nil
+		default:
+		}
+
+		reportDir := filepath.Join(ld.cfg.ClusterLoaderReportDir, fmt.Sprintf("run-%d", i))
+		if err = os.MkdirAll(reportDir, 0700); err != nil {
+			return err
+		}
+
+		ctx, cancel := ld.nativeRunContext()
+		rr, err := nd.run(ctx, i, reportDir)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("native run %d failed (%v)", i, err)
+		}
+		if ld.metrics != nil {
+			if err := ld.metrics.observeAndPush(i, rr); err != nil {
+				ld.cfg.Logger.Warn("failed to publish clusterloader metrics", zap.Int("index", i), zap.Error(err))
+			}
+		}
+		runs = append(runs, rr)
+	}
+	ld.resultsMu.Lock()
+	ld.results = clusterloaderapi.Aggregate(runs)
+	ld.resultsMu.Unlock()
+	return nil
+}
+
+// nativeRunContext returns a context bound to "ld.cfg.Timeout" that is also
+// canceled as soon as "Stopc" or "donec" fires, so "Stop()" can interrupt a
+// single in-flight "nd.run" (e.g. blocked in "waitForControlledPods"'s
+// polling loop) the same way "terminate" interrupts the binary-driven path,
+// rather than only being observed between "Runs" iterations. The returned
+// cancel must be called once the run completes to release the watcher
+// goroutine.
+func (ld *loader) nativeRunContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), ld.cfg.Timeout)
+	donec := make(chan struct{})
+	go func() {
+		select {
+		case <-ld.cfg.Stopc:
+			cancel()
+		case <-ld.donec:
+			cancel()
+		case <-donec:
+		}
+	}()
+	return ctx, func() {
+		close(donec)
+		cancel()
+	}
+}
+
+// run executes a single native measurement pass identified by "identifier",
+// and writes its JSON artifacts into "reportDir" in the same "PerfData"
+// format the binary-driven "clusterloader" writes, so existing perf-tests
+// report tooling can consume either.
+func (nd *nativeDriver) run(ctx context.Context, idx int, reportDir string) (clusterloaderapi.RunResult, error) {
+	nd.cfg.Logger.Info("running native cluster loader", zap.Int("index", idx), zap.String("identifier", nd.testConfig.Identifier))
+
+	rr := clusterloaderapi.RunResult{Index: idx}
+
+	apiResp, err := nd.measureAPIResponsiveness(ctx)
+	if err != nil {
+		return rr, fmt.Errorf("APIResponsiveness measurement failed (%v)", err)
+	}
+	if err = writeArtifact(reportDir, "APIResponsiveness", apiResp); err != nil {
+		return rr, err
+	}
+	rr.APIResponsiveness = apiResp
+
+	pods, err := nd.waitForControlledPods(ctx)
+	if err != nil {
+		return rr, fmt.Errorf("WaitForControlledPods failed (%v)", err)
+	}
+
+	startup := nd.measurePodStartupLatency(pods)
+	if err = writeArtifact(reportDir, "PodStartupLatency", startup); err != nil {
+		return rr, err
+	}
+	rr.PodStartupLatency = startup
+
+	scheduling := nd.measureSchedulingThroughput(pods)
+	if err = writeArtifact(reportDir, "SchedulingThroughput", scheduling); err != nil {
+		return rr, err
+	}
+	rr.SchedulingThroughput = scheduling
+
+	sysPods, err := nd.measureSystemPodMetrics(ctx)
+	if err != nil {
+		nd.cfg.Logger.Warn("SystemPodMetrics measurement failed", zap.Error(err))
+	} else {
+		if err = writeArtifact(reportDir, "SystemPodMetrics", sysPods); err != nil {
+			return rr, err
+		}
+		rr.SystemPodMetrics = sysPods
+	}
+
+	return rr, nil
+}
+
+// waitForControlledPods polls the cluster until "NodesPerNamespace *
+// PodsPerNode" pods exist and are running, or "ctx" is done, then returns
+// them. It's the native equivalent of clusterloader2's
+// "WaitForControlledPods" measurement.
+func (nd *nativeDriver) waitForControlledPods(ctx context.Context) ([]corev1.Pod, error) {
+	want := nd.cfg.NodesPerNamespace * nd.cfg.PodsPerNode
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		pods, err := nd.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		running := make([]corev1.Pod, 0, len(pods.Items))
+		for _, p := range pods.Items {
+			if p.Status.Phase == corev1.PodRunning {
+				running = append(running, p)
+			}
+		}
+		if want <= 0 || len(running) >= want {
+			return running, nil
+		}
+		select {
+		case <-ctx.Done():
+			return running, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// measurePodStartupLatency computes, from each pod's "CreationTimestamp" to
+// its "PodReady" condition's "LastTransitionTime", the pod-startup latency
+// distribution across "pods".
+func (nd *nativeDriver) measurePodStartupLatency(pods []corev1.Pod) *clusterloaderapi.PodStartupLatency {
+	samples := make([]float64, 0, len(pods))
+	for _, p := range pods {
+		for _, c := range p.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				latency := c.LastTransitionTime.Sub(p.CreationTimestamp.Time)
+				samples = append(samples, float64(latency.Milliseconds()))
+				break
+			}
+		}
+	}
+	return &clusterloaderapi.PodStartupLatency{
+		Version:   "1.0",
+		DataItems: []clusterloaderapi.DataItem{percentileDataItem(samples, "ms")},
+	}
+}
+
+// measureSchedulingThroughput reports how many of "pods" were scheduled per
+// second of wall-clock time between the earliest "CreationTimestamp" and the
+// latest scheduling condition's "LastTransitionTime".
+func (nd *nativeDriver) measureSchedulingThroughput(pods []corev1.Pod) *clusterloaderapi.SchedulingThroughput {
+	if len(pods) == 0 {
+		return &clusterloaderapi.SchedulingThroughput{Version: "1.0"}
+	}
+	earliest := pods[0].CreationTimestamp.Time
+	var latest time.Time
+	for _, p := range pods {
+		if p.CreationTimestamp.Time.Before(earliest) {
+			earliest = p.CreationTimestamp.Time
+		}
+		for _, c := range p.Status.Conditions {
+			if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionTrue && c.LastTransitionTime.After(latest) {
+				latest = c.LastTransitionTime.Time
+			}
+		}
+	}
+	elapsed := latest.Sub(earliest).Seconds()
+	throughput := 0.0
+	if elapsed > 0 {
+		throughput = float64(len(pods)) / elapsed
+	}
+	return &clusterloaderapi.SchedulingThroughput{
+		Version: "1.0",
+		DataItems: []clusterloaderapi.DataItem{{
+			Data: map[string]float64{"Perc50": throughput, "Perc90": throughput, "Perc99": throughput},
+			Unit: "pods/s",
+		}},
+	}
+}
+
+// measureSystemPodMetrics records each "kube-system" pod's restart count as a
+// coarse proxy for system pod health; detailed CPU/memory requires the
+// metrics-server API, which is not assumed to be installed.
+func (nd *nativeDriver) measureSystemPodMetrics(ctx context.Context) (*clusterloaderapi.SystemPodMetrics, error) {
+	pods, err := nd.clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]clusterloaderapi.DataItem, 0, len(pods.Items))
+	for _, p := range pods.Items {
+		restarts := int32(0)
+		for _, cs := range p.Status.ContainerStatuses {
+			restarts += cs.RestartCount
+		}
+		items = append(items, clusterloaderapi.DataItem{
+			Data:   map[string]float64{"restarts": float64(restarts)},
+			Unit:   "count",
+			Labels: map[string]string{"Pod": p.Name},
+		})
+	}
+	return &clusterloaderapi.SystemPodMetrics{Version: "1.0", DataItems: items}, nil
+}
+
+// measureAPIResponsiveness times a representative sample of read-only API
+// calls (the same ones clusterloader2's "APIResponsiveness" measurement
+// watches in practice) and reports their latency distribution.
+func (nd *nativeDriver) measureAPIResponsiveness(ctx context.Context) (*clusterloaderapi.APIResponsiveness, error) {
+	calls := []struct {
+		verb, resource string
+		do             func() error
+	}{
+		{"LIST", "nodes", func() error {
+			_, err := nd.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+			return err
+		}},
+		{"LIST", "pods", func() error {
+			_, err := nd.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+			return err
+		}},
+		{"LIST", "namespaces", func() error {
+			_, err := nd.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+			return err
+		}},
+	}
+
+	items := make([]clusterloaderapi.DataItem, 0, len(calls))
+	for _, c := range calls {
+		start := time.Now()
+		if err := c.do(); err != nil {
+			return nil, fmt.Errorf("%s %s failed (%v)", c.verb, c.resource, err)
+		}
+		ms := float64(time.Since(start).Milliseconds())
+		items = append(items, clusterloaderapi.DataItem{
+			Data:   map[string]float64{"Perc50": ms, "Perc90": ms, "Perc99": ms},
+			Unit:   "ms",
+			Labels: map[string]string{"Verb": c.verb, "Resource": c.resource},
+		})
+	}
+	return &clusterloaderapi.APIResponsiveness{Version: "1.0", DataItems: items}, nil
+}
+
+// percentileDataItem builds a single "DataItem" carrying the nearest-rank
+// p50/p90/p99 of "samples" under percentile-shaped keys, matching how
+// clusterloader2 measurements report a single metric's distribution.
+func percentileDataItem(samples []float64, unit string) clusterloaderapi.DataItem {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	get := func(p float64) float64 {
+		if len(sorted) == 0 {
+			return 0
+		}
+		idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(sorted) {
+			idx = len(sorted) - 1
+		}
+		return sorted[idx]
+	}
+	return clusterloaderapi.DataItem{
+		Data: map[string]float64{
+			"Perc50": get(50),
+			"Perc90": get(90),
+			"Perc99": get(99),
+		},
+		Unit: unit,
+	}
+}
+
+// writeArtifact marshals "v" to JSON and writes it to "reportDir" under the
+// filename clusterloader2 uses for "kind" (e.g. "APIResponsiveness.json"),
+// so the native driver's output is a drop-in replacement for the binary's.
+func writeArtifact(reportDir, kind string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s artifact (%v)", kind, err)
+	}
+	path := filepath.Join(reportDir, kind+".json")
+	if err = os.WriteFile(path, b, 0600); err != nil {
+		return fmt.Errorf("failed to write %s artifact to %q (%v)", kind, path, err)
+	}
+	return nil
+}